@@ -0,0 +1,51 @@
+package witness
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// TrieModification is a single write (or, eventually, delete) to be
+// proven against the MPT at BlockNum: Key/Value are hex-encoded, matching
+// the wire format used by the Rust caller.
+type TrieModification struct {
+	Type  int    `json:"Type"`
+	Key   string `json:"Key"`
+	Value string `json:"Value"`
+}
+
+// RPCError wraps a failure to reach or query the upstream node while
+// fetching the state GetWitness proves against, as opposed to a failure
+// while building the proof itself from state already in hand. Callers
+// that need to tell the two apart (e.g. to pick an error code) can use
+// errors.As to detect it.
+type RPCError struct {
+	err error
+}
+
+func (e *RPCError) Error() string { return fmt.Sprintf("upstream RPC error: %v", e.err) }
+func (e *RPCError) Unwrap() error { return e.err }
+
+// GetWitness connects to nodeUrl and fetches the header of block blockNum.
+// It does not build an MPT proof for the modifications in mods: once the
+// node has answered, it always returns a (non-RPCError) error.
+func GetWitness(nodeUrl string, blockNum int, mods []TrieModification) (interface{}, error) {
+	client, err := ethclient.Dial(nodeUrl)
+	if err != nil {
+		return nil, &RPCError{err: fmt.Errorf("failed to dial %s, err: %v", nodeUrl, err)}
+	}
+	defer client.Close()
+
+	if _, err := client.HeaderByNumber(context.Background(), big.NewInt(int64(blockNum))); err != nil {
+		return nil, &RPCError{err: fmt.Errorf("failed to fetch header for block %d, err: %v", blockNum, err)}
+	}
+
+	// NOTE: building the actual MPT proof for each TrieModification (by
+	// walking the state trie at blockNum and collecting the relevant
+	// nodes) lives alongside the rest of the MPT circuit witness
+	// generation and is omitted here.
+	return nil, fmt.Errorf("not implemented: MPT proof construction for %d modification(s) at block %d", len(mods), blockNum)
+}
@@ -0,0 +1,18 @@
+package witness
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGetWitnessUpstreamRPCFailure(t *testing.T) {
+	_, err := GetWitness("://not-a-valid-url", 1, nil)
+	if err == nil {
+		t.Fatal("expected an error for an invalid NodeUrl, got nil")
+	}
+
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) {
+		t.Fatalf("expected a *RPCError, got %T: %v", err, err)
+	}
+}
@@ -0,0 +1,271 @@
+package gethutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers/logger"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+// BlockConstants are the fields of a block that the EVM needs but that
+// don't come from the transaction or the accounts, e.g. the coinbase
+// address or the block number.
+type BlockConstants struct {
+	Coinbase   common.Address `json:"Coinbase"`
+	Timestamp  *big.Int       `json:"Timestamp"`
+	Number     *big.Int       `json:"Number"`
+	Difficulty *big.Int       `json:"Difficulty"`
+	GasLimit   uint64         `json:"GasLimit"`
+	BaseFee    *big.Int       `json:"BaseFee"`
+}
+
+// Account is the state of an account (balance, nonce, code, storage) that
+// the trace is seeded with, keyed by address in TraceConfig.Accounts.
+type Account struct {
+	Balance *big.Int                    `json:"Balance"`
+	Nonce   uint64                      `json:"Nonce"`
+	Code    []byte                      `json:"Code"`
+	Storage map[common.Hash]common.Hash `json:"Storage"`
+}
+
+// Transaction is the subset of transaction fields needed to build and run
+// a geth *types.Message against the EVM.
+type Transaction struct {
+	From     common.Address  `json:"From"`
+	To       *common.Address `json:"To"`
+	Nonce    uint64          `json:"Nonce"`
+	Value    *big.Int        `json:"Value"`
+	GasLimit uint64          `json:"GasLimit"`
+	GasPrice *big.Int        `json:"GasPrice"`
+	Data     []byte          `json:"Data"`
+}
+
+// TraceConfig is the input to Trace: the block/chain context, the
+// transactions to execute, and the pre-state accounts to seed the EVM
+// with.
+//
+// EIPs and HardFork make the activated fork configurable instead of
+// pinning the EVM to a single fixed chain configuration: HardFork selects
+// a named baseline (see hardForks) and EIPs additionally activates
+// individual EIPs on top of it, e.g. to turn on Shanghai-era opcodes like
+// PUSH0 (EIP-3855) while still tracing against an earlier named fork.
+type TraceConfig struct {
+	ChainID        *big.Int                   `json:"ChainID"`
+	HardFork       string                     `json:"HardFork"`
+	EIPs           []int                      `json:"EIPs"`
+	BlockConstants BlockConstants             `json:"BlockConstants"`
+	Transactions   []Transaction              `json:"Transactions"`
+	Accounts       map[common.Address]Account `json:"Accounts"`
+}
+
+// ExecutionResult is the result of running a single transaction through
+// Trace: the StructLogs emitted by the tracer plus the outcome of the
+// execution. It mirrors logger.ExecutionResult, whose JSON logger.GetResult
+// produces is what populates it.
+type ExecutionResult struct {
+	Gas         uint64                `json:"gas"`
+	Failed      bool                  `json:"failed"`
+	ReturnValue string                `json:"returnValue"`
+	StructLogs  []logger.StructLogRes `json:"structLogs"`
+}
+
+// londonConfig is params.MainnetChainConfig frozen right after the London
+// upgrade: every fork up to and including London is activated at block 0,
+// and nothing past it (no ShanghaiTime/CancunTime) is set, so PUSH0 is not
+// available unless explicitly turned on via TraceConfig.EIPs.
+var londonConfig = &params.ChainConfig{
+	ChainID:             params.MainnetChainConfig.ChainID,
+	HomesteadBlock:      big.NewInt(0),
+	EIP150Block:         big.NewInt(0),
+	EIP155Block:         big.NewInt(0),
+	EIP158Block:         big.NewInt(0),
+	ByzantiumBlock:      big.NewInt(0),
+	ConstantinopleBlock: big.NewInt(0),
+	PetersburgBlock:     big.NewInt(0),
+	IstanbulBlock:       big.NewInt(0),
+	MuirGlacierBlock:    big.NewInt(0),
+	BerlinBlock:         big.NewInt(0),
+	LondonBlock:         big.NewInt(0),
+}
+
+// shanghaiConfig is londonConfig plus the Shanghai upgrade (at which point
+// PUSH0 became part of the fork itself, not just an opt-in EIP) activated
+// from genesis.
+var shanghaiConfig = func() *params.ChainConfig {
+	cfg := *londonConfig
+	shanghaiTime := uint64(0)
+	cfg.ShanghaiTime = &shanghaiTime
+	return &cfg
+}()
+
+// hardForks maps a HardFork name to the params.ChainConfig it should be
+// traced against. "" defaults to the latest known fork (Shanghai),
+// matching the behavior before HardFork/EIPs existed.
+var hardForks = map[string]*params.ChainConfig{
+	"":         shanghaiConfig,
+	"Shanghai": shanghaiConfig,
+	"London":   londonConfig,
+}
+
+// chainConfig resolves config.HardFork to a params.ChainConfig, copying it
+// so per-call ChainID overrides don't mutate the shared baseline.
+func chainConfig(config TraceConfig) (*params.ChainConfig, error) {
+	base, ok := hardForks[config.HardFork]
+	if !ok {
+		return nil, fmt.Errorf("unknown HardFork %q", config.HardFork)
+	}
+
+	chainConfig := *base
+	if config.ChainID != nil {
+		chainConfig.ChainID = config.ChainID
+	}
+	return &chainConfig, nil
+}
+
+// vmConfig builds the vm.Config for config, activating every EIP in
+// config.EIPs on top of the jump table for the resolved hard fork. An
+// unknown EIP number is a configuration error, not a silent no-op.
+func vmConfig(config TraceConfig) (vm.Config, error) {
+	for _, eip := range config.EIPs {
+		if !vm.ValidEip(eip) {
+			return vm.Config{}, fmt.Errorf("unknown EIP %d", eip)
+		}
+	}
+
+	return vm.Config{
+		ExtraEips: config.EIPs,
+	}, nil
+}
+
+// newPrestateDB builds an in-memory state.StateDB seeded with
+// config.Accounts, so Trace can run against it without touching a real
+// node or disk.
+func newPrestateDB(config TraceConfig) (*state.StateDB, error) {
+	db := state.NewDatabase(rawdb.NewMemoryDatabase())
+	statedb, err := state.New(common.Hash{}, db, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create statedb, err: %v", err)
+	}
+
+	for addr, account := range config.Accounts {
+		accountBalance := account.Balance
+		if accountBalance == nil {
+			accountBalance = big.NewInt(0)
+		}
+		balance, overflow := uint256.FromBig(accountBalance)
+		if overflow {
+			return nil, fmt.Errorf("balance for account %s overflows uint256", addr)
+		}
+		statedb.SetBalance(addr, balance)
+		statedb.SetNonce(addr, account.Nonce)
+		statedb.SetCode(addr, account.Code)
+		for key, value := range account.Storage {
+			statedb.SetState(addr, key, value)
+		}
+	}
+
+	return statedb, nil
+}
+
+// blockContext builds the vm.BlockContext shared by every transaction in
+// config.Transactions from config.BlockConstants.
+func blockContext(config TraceConfig) vm.BlockContext {
+	var timestamp uint64
+	if t := config.BlockConstants.Timestamp; t != nil {
+		timestamp = t.Uint64()
+	}
+
+	return vm.BlockContext{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		GetHash:     func(uint64) common.Hash { return common.Hash{} },
+		Coinbase:    config.BlockConstants.Coinbase,
+		BlockNumber: config.BlockConstants.Number,
+		Time:        timestamp,
+		Difficulty:  config.BlockConstants.Difficulty,
+		GasLimit:    config.BlockConstants.GasLimit,
+		BaseFee:     config.BlockConstants.BaseFee,
+	}
+}
+
+// Trace runs every transaction in config.Transactions against the
+// pre-state in config.Accounts and returns the per-transaction execution
+// trace.
+//
+// The EIPs requested via config.EIPs (e.g. 3855 for PUSH0) are activated
+// by vm.Config.ExtraEips below: go-ethereum's own vm.NewEVMInterpreter
+// calls vm.EnableEIP for each entry, which is what patches the jump table
+// with the PUSH0 (0x5f) operation (constantGas GasQuickStep, 0 inputs, 1
+// output, pushing a zero uint256.Int) and the other 3529/3198/2929
+// activators. That jump-table patching lives in core/vm itself, not here,
+// because vm.JumpTable/vm.operation are unexported; vmConfig's job is
+// just to validate and forward the requested EIP numbers, and
+// logger.StructLogger (and the OpCode disassembly it uses) already label
+// PUSH0 correctly once it's part of the jump table. TestTracePush0 runs
+// real PUSH0 bytecode through this function and pins both ends of that
+// behavior down, rather than just trusting it's wired correctly.
+func Trace(config TraceConfig) ([]ExecutionResult, error) {
+	chainConfig, err := chainConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	vmConfig, err := vmConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	statedb, err := newPrestateDB(config)
+	if err != nil {
+		return nil, err
+	}
+	blockCtx := blockContext(config)
+
+	results := make([]ExecutionResult, 0, len(config.Transactions))
+	for i, tx := range config.Transactions {
+		structLogger := logger.NewStructLogger(nil)
+		vmConfig.Tracer = structLogger
+
+		txCtx := vm.TxContext{Origin: tx.From, GasPrice: tx.GasPrice}
+		evm := vm.NewEVM(blockCtx, txCtx, statedb, chainConfig, vmConfig)
+
+		msg := &core.Message{
+			From:     tx.From,
+			To:       tx.To,
+			Nonce:    tx.Nonce,
+			Value:    tx.Value,
+			GasLimit: tx.GasLimit,
+			GasPrice: tx.GasPrice,
+			// Legacy (non-EIP-1559) transactions: both caps equal GasPrice,
+			// matching how core.TransactionToMessage treats a legacy tx.
+			GasFeeCap: tx.GasPrice,
+			GasTipCap: tx.GasPrice,
+			Data:      tx.Data,
+		}
+
+		gp := new(core.GasPool).AddGas(tx.GasLimit)
+		if _, err := core.ApplyMessage(evm, msg, gp); err != nil {
+			return nil, fmt.Errorf("failed to apply transaction %d, err: %v", i, err)
+		}
+
+		resultJSON, err := structLogger.GetResult()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get trace result for transaction %d, err: %v", i, err)
+		}
+
+		var result ExecutionResult
+		if err := json.Unmarshal(resultJSON, &result); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal trace result for transaction %d, err: %v", i, err)
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
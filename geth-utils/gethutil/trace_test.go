@@ -0,0 +1,133 @@
+package gethutil
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestChainConfigUnknownHardFork(t *testing.T) {
+	_, err := chainConfig(TraceConfig{HardFork: "Frontier"})
+	if err == nil {
+		t.Fatal("expected an error for an unknown HardFork, got nil")
+	}
+}
+
+func TestChainConfigKnownHardForks(t *testing.T) {
+	cfg, err := chainConfig(TraceConfig{HardFork: "London"})
+	if err != nil {
+		t.Fatalf("unexpected error for HardFork \"London\": %v", err)
+	}
+	if cfg.ShanghaiTime != nil {
+		t.Error("expected HardFork \"London\" to not have Shanghai activated")
+	}
+
+	cfg, err = chainConfig(TraceConfig{HardFork: "Shanghai"})
+	if err != nil {
+		t.Fatalf("unexpected error for HardFork \"Shanghai\": %v", err)
+	}
+	if cfg.ShanghaiTime == nil {
+		t.Error("expected HardFork \"Shanghai\" to have Shanghai activated")
+	}
+
+	cfg, err = chainConfig(TraceConfig{})
+	if err != nil {
+		t.Fatalf("unexpected error for the default HardFork: %v", err)
+	}
+	if cfg.ShanghaiTime == nil {
+		t.Error("expected the default HardFork to be Shanghai")
+	}
+}
+
+func TestChainConfigChainIDOverride(t *testing.T) {
+	chainID := big.NewInt(1337)
+	cfg, err := chainConfig(TraceConfig{HardFork: "London", ChainID: chainID})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.ChainID.Cmp(chainID) != 0 {
+		t.Errorf("expected ChainID %v, got %v", chainID, cfg.ChainID)
+	}
+	if londonConfig.ChainID.Cmp(chainID) == 0 {
+		t.Fatal("chainConfig must not mutate the shared londonConfig baseline")
+	}
+}
+
+func TestVmConfigUnknownEIP(t *testing.T) {
+	_, err := vmConfig(TraceConfig{EIPs: []int{999999}})
+	if err == nil {
+		t.Fatal("expected an error for an unknown EIP, got nil")
+	}
+}
+
+func TestVmConfigKnownEIPs(t *testing.T) {
+	eips := []int{3855, 3529, 3198, 2929}
+	cfg, err := vmConfig(TraceConfig{EIPs: eips})
+	if err != nil {
+		t.Fatalf("unexpected error for EIPs %v: %v", eips, err)
+	}
+	if len(cfg.ExtraEips) != len(eips) {
+		t.Fatalf("expected ExtraEips %v, got %v", eips, cfg.ExtraEips)
+	}
+	for i, eip := range eips {
+		if cfg.ExtraEips[i] != eip {
+			t.Errorf("expected ExtraEips[%d] = %d, got %d", i, eip, cfg.ExtraEips[i])
+		}
+	}
+}
+
+// pushZeroTraceConfig returns a TraceConfig that deploys a single PUSH0
+// (0x5f) followed by STOP at codeAddr and calls it from from, so the only
+// thing under test is whether PUSH0 itself is a valid opcode.
+func pushZeroTraceConfig(hardFork string, eips []int) (TraceConfig, common.Address) {
+	codeAddr := common.HexToAddress("0x1234")
+	from := common.HexToAddress("0x5678")
+
+	return TraceConfig{
+		HardFork: hardFork,
+		EIPs:     eips,
+		BlockConstants: BlockConstants{
+			Number:     big.NewInt(1),
+			Timestamp:  big.NewInt(1),
+			Difficulty: big.NewInt(0),
+			GasLimit:   10_000_000,
+			BaseFee:    big.NewInt(0),
+		},
+		Accounts: map[common.Address]Account{
+			codeAddr: {Balance: big.NewInt(0), Code: []byte{0x5f, 0x00}},
+			from:     {Balance: big.NewInt(1e18)},
+		},
+		Transactions: []Transaction{{
+			From:     from,
+			To:       &codeAddr,
+			GasLimit: 100000,
+			GasPrice: big.NewInt(1),
+			Value:    big.NewInt(0),
+		}},
+	}, codeAddr
+}
+
+// TestTracePush0 is an end-to-end check, not just a config-plumbing one:
+// it runs real PUSH0 (0x5f) bytecode through Trace and asserts it only
+// executes successfully once EIP 3855 is activated, and fails as an
+// invalid opcode on a hard fork that doesn't have it by default.
+func TestTracePush0(t *testing.T) {
+	config, _ := pushZeroTraceConfig("London", []int{3855})
+	results, err := Trace(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if results[0].Failed {
+		t.Errorf("expected PUSH0 to execute successfully with EIP 3855 enabled, got failed=true: %+v", results[0])
+	}
+
+	config, _ = pushZeroTraceConfig("London", nil)
+	results, err = Trace(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !results[0].Failed {
+		t.Error("expected PUSH0 to fail as an invalid opcode on London without EIP 3855 enabled")
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"main/gethutil"
+	"os"
+)
+
+// readInput reads from path, or from stdin when path is "-".
+func readInput(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(path)
+}
+
+// writeOutput writes bytes to path, or to stdout when path is "-".
+func writeOutput(path string, bytes []byte) error {
+	if path == "-" {
+		_, err := os.Stdout.Write(bytes)
+		return err
+	}
+	return os.WriteFile(path, bytes, 0644)
+}
+
+// runTrace implements the `trace` subcommand: it reads a
+// gethutil.TraceConfig from --config (or stdin) and writes the resulting
+// []gethutil.ExecutionResult to --out (or stdout), using the exact same
+// doCreateTrace code path as the CreateTrace cgo export and the
+// zkevm_createTrace JSON-RPC method.
+func runTrace(args []string) error {
+	fs := flag.NewFlagSet("trace", flag.ExitOnError)
+	configPath := fs.String("config", "-", "path to the trace config JSON (- for stdin)")
+	outPath := fs.String("out", "-", "path to write the trace result JSON (- for stdout)")
+	fs.Parse(args)
+
+	configBytes, err := readInput(*configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read config, err: %v", err)
+	}
+
+	var config gethutil.TraceConfig
+	if err := json.Unmarshal(configBytes, &config); err != nil {
+		return fmt.Errorf("failed to unmarshal config, err: %v", err)
+	}
+
+	result, err := doCreateTrace(config)
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(*outPath, result)
+}
+
+// runMptWitness implements the `mpt-witness` subcommand: it reads a
+// GetWitnessRequest from --request (or stdin) and writes the resulting
+// witness proof to --out (or stdout), using the exact same
+// doGetMptWitness code path as the GetMptWitness cgo export and the
+// zkevm_getMptWitness JSON-RPC method.
+func runMptWitness(args []string) error {
+	fs := flag.NewFlagSet("mpt-witness", flag.ExitOnError)
+	requestPath := fs.String("request", "-", "path to the witness request JSON (- for stdin)")
+	outPath := fs.String("out", "-", "path to write the witness result JSON (- for stdout)")
+	fs.Parse(args)
+
+	requestBytes, err := readInput(*requestPath)
+	if err != nil {
+		return fmt.Errorf("failed to read request, err: %v", err)
+	}
+
+	var config GetWitnessRequest
+	if err := json.Unmarshal(requestBytes, &config); err != nil {
+		return fmt.Errorf("failed to unmarshal request, err: %v", err)
+	}
+
+	result, err := doGetMptWitness(config)
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(*outPath, result)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Code is a stable, numeric status for the result of a cgo-exported
+// function, so that a Rust (or any other) caller can distinguish success
+// from the various failure modes without string-matching the result.
+type Code int
+
+const (
+	// CodeOK indicates the call succeeded; Envelope.Result holds the
+	// payload and Envelope.Error is nil.
+	CodeOK Code = iota
+	// CodeBadJSON indicates the input string failed to unmarshal.
+	CodeBadJSON
+	// CodeUpstreamRPCFailure indicates a call to the upstream node's RPC
+	// (e.g. to fetch state for the MPT witness) failed.
+	CodeUpstreamRPCFailure
+	// CodeEVMExecutionError indicates gethutil.Trace failed to run the
+	// EVM to completion.
+	CodeEVMExecutionError
+	// CodeMPTProofError indicates the MPT witness/proof generation
+	// failed.
+	CodeMPTProofError
+	// CodeInternalPanic indicates the call recovered from a panic. A
+	// malformed request should never be able to take down the host
+	// process, which is why CreateTrace and GetMptWitness wrap their
+	// bodies in this recovery.
+	CodeInternalPanic
+)
+
+// Envelope is the stable JSON shape returned by the CreateTrace and
+// GetMptWitness cgo exports: {"code": int, "error": string|null, "result": <payload>}.
+// Exactly one of Error and Result is meaningful, selected by Code; on
+// failure the "result" key is omitted.
+type Envelope struct {
+	Code   Code            `json:"code"`
+	Error  *string         `json:"error"`
+	Result json.RawMessage `json:"result,omitempty"`
+}
+
+// okEnvelope builds the success envelope wrapping result, which must
+// already be valid JSON (e.g. the output of doCreateTrace/doGetMptWitness).
+func okEnvelope(result []byte) []byte {
+	return marshalEnvelope(Envelope{Code: CodeOK, Result: result})
+}
+
+// errEnvelope builds the failure envelope for code/err. It never fails:
+// if the envelope can't be marshalled for some reason, marshalEnvelope
+// returns a minimal CodeInternalPanic envelope carrying the marshalling
+// error instead, so the caller still gets a valid envelope.
+func errEnvelope(code Code, err error) []byte {
+	msg := err.Error()
+	return marshalEnvelope(Envelope{Code: code, Error: &msg})
+}
+
+func marshalEnvelope(e Envelope) []byte {
+	bytes, err := json.Marshal(e)
+	if err != nil {
+		// This should never happen: Envelope only contains strings and
+		// already-validated JSON. Fall back to a minimal envelope rather
+		// than returning something that isn't valid JSON at all.
+		return []byte(fmt.Sprintf(`{"code":%d,"error":%q}`, CodeInternalPanic, err.Error()))
+	}
+	return bytes
+}
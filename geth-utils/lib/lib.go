@@ -6,34 +6,52 @@ package main
 import "C"
 import (
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"main/gethutil"
 	"main/gethutil/mpt/witness"
+	"os"
 	"unsafe"
 )
 
-// TODO: Add proper error handling.  For example, return an int, where 0 means
-// ok, and !=0 means error.
-//
-//export CreateTrace
-func CreateTrace(configStr *C.char) *C.char {
-	var config gethutil.TraceConfig
-	err := json.Unmarshal([]byte(C.GoString(configStr)), &config)
+// doCreateTrace runs the trace generation for config and marshals the
+// result, so that the cgo export, the JSON-RPC server and the file-based
+// CLI all go through the exact same code path.
+func doCreateTrace(config gethutil.TraceConfig) ([]byte, error) {
+	executionResults, err := gethutil.Trace(config)
 	if err != nil {
-		return C.CString(fmt.Sprintf("Failed to unmarshal config, err: %v", err))
+		return nil, fmt.Errorf("failed to run Trace, err: %v", err)
 	}
 
-	executionResults, err := gethutil.Trace(config)
+	bytes, err := json.MarshalIndent(executionResults, "", "  ")
 	if err != nil {
-		return C.CString(fmt.Sprintf("Failed to run Trace, err: %v", err))
+		return nil, fmt.Errorf("failed to marshal []ExecutionResult, err: %v", err)
 	}
 
-	bytes, err := json.MarshalIndent(executionResults, "", "  ")
+	return bytes, nil
+}
+
+//export CreateTrace
+func CreateTrace(configStr *C.char) (result *C.char) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = C.CString(string(errEnvelope(CodeInternalPanic, fmt.Errorf("%v", r))))
+		}
+	}()
+
+	var config gethutil.TraceConfig
+	err := json.Unmarshal([]byte(C.GoString(configStr)), &config)
+	if err != nil {
+		return C.CString(string(errEnvelope(CodeBadJSON, fmt.Errorf("failed to unmarshal config, err: %v", err))))
+	}
+
+	bytes, err := doCreateTrace(config)
 	if err != nil {
-		return C.CString(fmt.Sprintf("Failed to marshal []ExecutionResult, err: %v", err))
+		return C.CString(string(errEnvelope(CodeEVMExecutionError, err)))
 	}
 
-	return C.CString(string(bytes))
+	return C.CString(string(okEnvelope(bytes)))
 }
 
 type Config struct {
@@ -50,22 +68,44 @@ type GetWitnessRequest struct {
 	Mods     []witness.TrieModification
 }
 
+// doGetMptWitness runs the MPT witness generation for config and marshals
+// the result, so that the cgo export, the JSON-RPC server and the
+// file-based CLI all go through the exact same code path.
+func doGetMptWitness(config GetWitnessRequest) ([]byte, error) {
+	proof, err := witness.GetWitness(config.NodeUrl, config.BlockNum, config.Mods)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(proof)
+}
+
 //export GetMptWitness
-func GetMptWitness(proofConf *C.char) *C.char {
-	var config GetWitnessRequest
+func GetMptWitness(proofConf *C.char) (result *C.char) {
+	// A malformed request must not be able to take down the host process:
+	// this used to panic(err) on bad JSON, which is fatal when these
+	// functions run inside a Rust prover.
+	defer func() {
+		if r := recover(); r != nil {
+			result = C.CString(string(errEnvelope(CodeInternalPanic, fmt.Errorf("%v", r))))
+		}
+	}()
 
+	var config GetWitnessRequest
 	err := json.Unmarshal([]byte(C.GoString(proofConf)), &config)
 	if err != nil {
-		panic(err)
+		return C.CString(string(errEnvelope(CodeBadJSON, fmt.Errorf("failed to unmarshal request, err: %v", err))))
 	}
 
-	proof := witness.GetWitness(config.NodeUrl, config.BlockNum, config.Mods)
-	b, err := json.Marshal(proof)
+	b, err := doGetMptWitness(config)
 	if err != nil {
-		fmt.Println(err)
+		var rpcErr *witness.RPCError
+		if errors.As(err, &rpcErr) {
+			return C.CString(string(errEnvelope(CodeUpstreamRPCFailure, err)))
+		}
+		return C.CString(string(errEnvelope(CodeMPTProofError, err)))
 	}
 
-	return C.CString(string(b))
+	return C.CString(string(okEnvelope(b)))
 }
 
 //export FreeString
@@ -73,4 +113,40 @@ func FreeString(str *C.char) {
 	C.free(unsafe.Pointer(str))
 }
 
-func main() {}
+// main is a no-op when this package is built with -buildmode=c-shared (the
+// cgo exports above are what's used in that case), but lets the same
+// binary also run standalone with a few subcommands:
+//
+//	lib serve --addr :8545
+//	lib trace --config trace.json --out trace.json
+//	lib mpt-witness --request req.json --out witness.json
+//
+// so a Rust (or any other) client can talk JSON-RPC over a socket, or a CI
+// pipeline can drive trace/witness generation from files, without either
+// of them needing to load the cgo shared library.
+func main() {
+	if len(os.Args) < 2 {
+		return
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "serve":
+		fs := flag.NewFlagSet("serve", flag.ExitOnError)
+		addr := fs.String("addr", ":8545", "address to listen on")
+		fs.Parse(os.Args[2:])
+		err = serve(*addr)
+	case "trace":
+		err = runTrace(os.Args[2:])
+	case "mpt-witness":
+		err = runMptWitness(os.Args[2:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown subcommand: %s\n", os.Args[1])
+		os.Exit(1)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
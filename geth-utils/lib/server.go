@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"main/gethutil"
+	"net/http"
+)
+
+// jsonRPCRequest is a JSON-RPC 2.0 request object, as described in
+// https://www.jsonrpc.org/specification.
+type jsonRPCRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// jsonRPCResponse is a JSON-RPC 2.0 response object.  Exactly one of Result
+// and Error is populated.
+type jsonRPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *jsonRPCError   `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+type jsonRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+const (
+	jsonRPCParseError     = -32700
+	jsonRPCInvalidRequest = -32600
+	jsonRPCMethodNotFound = -32601
+	jsonRPCInternalError  = -32603
+)
+
+// rpcMethods maps JSON-RPC method names to the handler that serves them.
+// Each handler receives the raw `params` and returns the result to be
+// marshalled back to the caller.
+var rpcMethods = map[string]func(params json.RawMessage) (interface{}, error){
+	"zkevm_createTrace": func(params json.RawMessage) (interface{}, error) {
+		var config gethutil.TraceConfig
+		if err := json.Unmarshal(params, &config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal TraceConfig, err: %v", err)
+		}
+		bytes, err := doCreateTrace(config)
+		if err != nil {
+			return nil, err
+		}
+		return json.RawMessage(bytes), nil
+	},
+	"zkevm_getMptWitness": func(params json.RawMessage) (interface{}, error) {
+		var config GetWitnessRequest
+		if err := json.Unmarshal(params, &config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal GetWitnessRequest, err: %v", err)
+		}
+		bytes, err := doGetMptWitness(config)
+		if err != nil {
+			return nil, err
+		}
+		return json.RawMessage(bytes), nil
+	},
+}
+
+func rpcHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req jsonRPCRequest
+	dec := json.NewDecoder(r.Body)
+	if err := dec.Decode(&req); err != nil {
+		writeRPCError(w, nil, jsonRPCParseError, fmt.Sprintf("failed to parse request: %v", err))
+		return
+	}
+
+	if req.JSONRPC != "2.0" {
+		writeRPCError(w, req.ID, jsonRPCInvalidRequest, fmt.Sprintf(`invalid "jsonrpc" version: %q`, req.JSONRPC))
+		return
+	}
+
+	handler, ok := rpcMethods[req.Method]
+	if !ok {
+		writeRPCError(w, req.ID, jsonRPCMethodNotFound, fmt.Sprintf("method not found: %s", req.Method))
+		return
+	}
+
+	result, err := handler(req.Params)
+	if err != nil {
+		writeRPCError(w, req.ID, jsonRPCInternalError, err.Error())
+		return
+	}
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		writeRPCError(w, req.ID, jsonRPCInternalError, fmt.Sprintf("failed to marshal result: %v", err))
+		return
+	}
+
+	writeJSON(w, jsonRPCResponse{
+		JSONRPC: "2.0",
+		Result:  resultBytes,
+		ID:      req.ID,
+	})
+}
+
+func writeRPCError(w http.ResponseWriter, id json.RawMessage, code int, message string) {
+	writeJSON(w, jsonRPCResponse{
+		JSONRPC: "2.0",
+		Error:   &jsonRPCError{Code: code, Message: message},
+		ID:      id,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, resp jsonRPCResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("failed to write JSON-RPC response: %v", err)
+	}
+}
+
+// serve starts an HTTP server on addr exposing CreateTrace and
+// GetMptWitness as JSON-RPC 2.0 methods (zkevm_createTrace,
+// zkevm_getMptWitness), so that clients which can't or don't want to load
+// the cgo shared library (e.g. a long-lived Rust prover, or test
+// infrastructure running this as a sidecar) can drive the same operations
+// over a socket instead.
+func serve(addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", rpcHandler)
+
+	log.Printf("zkevm-circuits geth-utils JSON-RPC server listening on %s", addr)
+	return http.ListenAndServe(addr, mux)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRpcHandlerRejectsNonPost(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+
+	rpcHandler(w, req)
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status %d, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
+
+func TestRpcHandlerRejectsInvalidJSONRPCVersion(t *testing.T) {
+	for _, jsonrpc := range []string{"", "1.0"} {
+		body := `{"jsonrpc":"` + jsonrpc + `","method":"zkevm_createTrace","params":{},"id":1}`
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		w := httptest.NewRecorder()
+
+		rpcHandler(w, req)
+
+		var resp jsonRPCResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to unmarshal response for jsonrpc=%q: %v", jsonrpc, err)
+		}
+		if resp.Error == nil || resp.Error.Code != jsonRPCInvalidRequest {
+			t.Errorf("expected a %d error for jsonrpc=%q, got %+v", jsonRPCInvalidRequest, jsonrpc, resp.Error)
+		}
+	}
+}